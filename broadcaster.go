@@ -0,0 +1,300 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// listenerBufSize is the depth of each consumer's frame channel. Once full,
+// the broadcaster drops the oldest buffered frame rather than blocking the
+// capture pipeline.
+const listenerBufSize = 4
+
+// listener is a single fan-out destination registered with a
+// BroadcastManager. A listener that asked to waitForKeyframe sits in the
+// manager's lobby (listenersKf) until the next keyframe boundary promotes
+// it into listeners.
+type listener struct {
+	id              int
+	frames          chan []byte
+	fn              func(frame []byte)
+	waitForKeyframe bool
+}
+
+// pipelineSource is the subset of *Pipeline that BroadcastManager depends
+// on. It exists so tests can exercise listener/lobby bookkeeping against a
+// fake capture source instead of a real GStreamer pipeline.
+type pipelineSource interface {
+	Frames() <-chan []byte
+	Play() error
+	Destroy() error
+}
+
+// BroadcastManager owns the capture pipeline and fans every frame it
+// produces out to N registered consumers (face detection, MJPEG snapshot,
+// RTMP re-broadcast, ...). The pipeline is started lazily on the first
+// AddListener call and torn down once the last listener is removed, so an
+// idle instance with nobody watching doesn't keep a GStreamer graph alive.
+type BroadcastManager struct {
+	newPipeline func(desc string) (pipelineSource, error)
+
+	mu          sync.Mutex
+	desc        string
+	pipeline    pipelineSource
+	listeners   map[int]*listener
+	listenersKf map[int]*listener // lobby: promoted to listeners on the next keyframe boundary
+	nextID      int
+	stopped     chan struct{}
+	stopping    bool
+	onEnded     func()
+}
+
+// SetOnEnded registers fn to be called whenever the capture pipeline ends
+// on its own (EOS or error) rather than via an explicit Stop/Restart. The
+// Supervisor uses this to detect a dead stream and restart it with
+// backoff.
+func (b *BroadcastManager) SetOnEnded(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onEnded = fn
+}
+
+// NewBroadcastManager returns a manager that builds its capture pipeline
+// on demand using newPipeline (typically a closure over NewPipeline), with
+// desc as the initial pipeline description.
+func NewBroadcastManager(desc string, newPipeline func(desc string) (pipelineSource, error)) *BroadcastManager {
+	return &BroadcastManager{
+		newPipeline: newPipeline,
+		desc:        desc,
+		listeners:   make(map[int]*listener),
+		listenersKf: make(map[int]*listener),
+	}
+}
+
+// AddListener registers fn to receive every frame captured from the
+// pipeline and starts the pipeline if this is the first listener. It
+// returns an id that must be passed to RemoveListener.
+//
+// If waitForKeyframe is true, the listener is parked in a lobby and only
+// starts receiving frames once the broadcaster observes the next keyframe
+// boundary, so a consumer that just attached mid-stream (or right as the
+// pipeline restarts) never sees a partial or stale frame.
+func (b *BroadcastManager) AddListener(fn func(frame []byte), waitForKeyframe bool) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l := &listener{
+		id:              b.nextID,
+		frames:          make(chan []byte, listenerBufSize),
+		fn:              fn,
+		waitForKeyframe: waitForKeyframe,
+	}
+	b.nextID++
+	go b.deliver(l)
+
+	if waitForKeyframe {
+		b.listenersKf[l.id] = l
+	} else {
+		b.listeners[l.id] = l
+	}
+
+	if b.pipeline == nil {
+		if err := b.startLocked(); err != nil {
+			delete(b.listeners, l.id)
+			delete(b.listenersKf, l.id)
+			close(l.frames)
+			return 0, err
+		}
+	}
+
+	return l.id, nil
+}
+
+// RemoveListener unregisters the listener previously returned by
+// AddListener (whether still in the lobby or already promoted) and
+// destroys the capture pipeline once no listeners remain.
+func (b *BroadcastManager) RemoveListener(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l, ok := b.listeners[id]
+	if ok {
+		delete(b.listeners, id)
+	} else if l, ok = b.listenersKf[id]; ok {
+		delete(b.listenersKf, id)
+	} else {
+		return
+	}
+	close(l.frames)
+
+	if len(b.listeners)+len(b.listenersKf) == 0 && b.pipeline != nil {
+		b.stopLocked()
+	}
+}
+
+// SetPipelineDesc replaces the pipeline description used for the next
+// (re)start. Call Restart to rebuild the GStreamer graph immediately.
+func (b *BroadcastManager) SetPipelineDesc(desc string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.desc = desc
+}
+
+// Start explicitly (re)starts the capture pipeline, independent of the
+// lazy-start-on-listener behavior of AddListener. It is a no-op if the
+// pipeline is already running.
+func (b *BroadcastManager) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pipeline != nil {
+		return nil
+	}
+	return b.startLocked()
+}
+
+// Stop tears down the capture pipeline without removing any registered
+// listeners; they resume receiving frames on the next Start/Restart.
+func (b *BroadcastManager) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopLocked()
+}
+
+// Restart tears down the current pipeline (if any) and rebuilds it from
+// the current pipeline description, picking up any change made via
+// SetPipelineDesc. Existing listeners stay registered throughout.
+func (b *BroadcastManager) Restart() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopLocked()
+	return b.startLocked()
+}
+
+// startLocked builds and plays a new pipeline from b.desc. b.mu must be
+// held by the caller.
+func (b *BroadcastManager) startLocked() error {
+	pl, err := b.newPipeline(b.desc)
+	if err != nil {
+		return err
+	}
+	if err := pl.Play(); err != nil {
+		return err
+	}
+	b.pipeline = pl
+	b.stopped = make(chan struct{})
+	b.stopping = false
+	go b.pump(pl, b.stopped)
+	log.Println("BroadcastManager: capture pipeline started")
+	return nil
+}
+
+// stopLocked destroys the current pipeline, if any. b.mu must be held by
+// the caller.
+func (b *BroadcastManager) stopLocked() {
+	if b.pipeline == nil {
+		return
+	}
+	b.stopping = true
+	close(b.stopped)
+	_ = b.pipeline.Destroy()
+	b.pipeline = nil
+	b.relobbyLocked()
+	log.Println("BroadcastManager: capture pipeline stopped")
+}
+
+// relobbyLocked moves every already-promoted listener back into the
+// keyframe lobby, so that on the next startLocked nobody — not just a
+// listener that attaches after this point — resumes receiving frames
+// until the new pipeline reaches a keyframe boundary. b.mu must be held
+// by the caller.
+func (b *BroadcastManager) relobbyLocked() {
+	for id, l := range b.listeners {
+		b.listenersKf[id] = l
+		delete(b.listeners, id)
+	}
+}
+
+// pump reads frames off the pipeline and fans them out to every registered
+// listener until the pipeline ends or the manager is stopped.
+func (b *BroadcastManager) pump(pl pipelineSource, stopped chan struct{}) {
+	for {
+		select {
+		case frame, ok := <-pl.Frames():
+			if !ok {
+				b.handleEnded(pl)
+				return
+			}
+			b.broadcast(frame)
+		case <-stopped:
+			return
+		}
+	}
+}
+
+// handleEnded runs when the pipeline's frame channel closes on its own
+// (EOS or error). It is a no-op if the channel closed because of an
+// explicit Stop/Restart instead, including when a newer pipeline has
+// already replaced pl.
+func (b *BroadcastManager) handleEnded(pl pipelineSource) {
+	b.mu.Lock()
+	unexpected := !b.stopping && b.pipeline == pl
+	if unexpected {
+		b.pipeline = nil
+		b.relobbyLocked()
+	}
+	onEnded := b.onEnded
+	b.mu.Unlock()
+
+	if unexpected && onEnded != nil {
+		onEnded()
+	}
+}
+
+func (b *BroadcastManager) broadcast(frame []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Promote any lobby listeners to active once we observe a keyframe
+	// boundary, so they don't get fed a frame until they can be sure it's
+	// fully valid.
+	if len(b.listenersKf) > 0 && isKeyframeBoundary(frame) {
+		for id, l := range b.listenersKf {
+			b.listeners[id] = l
+			delete(b.listenersKf, id)
+		}
+	}
+
+	for _, l := range b.listeners {
+		select {
+		case l.frames <- frame:
+		default:
+			// Drop the oldest buffered frame to make room, then enqueue.
+			select {
+			case <-l.frames:
+			default:
+			}
+			select {
+			case l.frames <- frame:
+			default:
+			}
+		}
+	}
+}
+
+// deliver invokes l.fn for every frame queued for l until its channel is
+// closed by RemoveListener.
+func (b *BroadcastManager) deliver(l *listener) {
+	for frame := range l.frames {
+		l.fn(frame)
+	}
+}
+
+// isKeyframeBoundary reports whether frame is a valid point to promote
+// lobby listeners on. Raw GRAY8 captures have no delta-coded frames: every
+// sample handed up from the appsink is already a complete, frameSize-sized
+// buffer, so it always qualifies. A future H264 passthrough consumer would
+// instead need to check the originating sample for
+// !GST_BUFFER_FLAG_DELTA_UNIT before treating it as a keyframe.
+func isKeyframeBoundary(frame []byte) bool {
+	return true
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakePipeline is a pipelineSource test double that lets tests push frames
+// and simulate EOS/errors without a real GStreamer pipeline.
+type fakePipeline struct {
+	frames    chan []byte
+	destroyed bool
+}
+
+func newFakePipeline(desc string) (pipelineSource, error) {
+	if desc == "bad" {
+		return nil, errors.New("bad pipeline")
+	}
+	return &fakePipeline{frames: make(chan []byte, 8)}, nil
+}
+
+func (p *fakePipeline) Frames() <-chan []byte { return p.frames }
+func (p *fakePipeline) Play() error           { return nil }
+func (p *fakePipeline) Destroy() error {
+	p.destroyed = true
+	close(p.frames)
+	return nil
+}
+
+func waitFor(t *testing.T, ch <-chan []byte) []byte {
+	t.Helper()
+	select {
+	case frame := <-ch:
+		return frame
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for frame")
+		return nil
+	}
+}
+
+func TestBroadcastManagerStartsOnFirstListener(t *testing.T) {
+	b := NewBroadcastManager("desc", newFakePipeline)
+
+	id, err := b.AddListener(func([]byte) {}, false)
+	if err != nil {
+		t.Fatalf("AddListener: %v", err)
+	}
+	if b.pipeline == nil {
+		t.Fatal("expected pipeline to be started lazily on first listener")
+	}
+
+	b.RemoveListener(id)
+	if b.pipeline != nil {
+		t.Fatal("expected pipeline to be destroyed once the last listener is removed")
+	}
+}
+
+func TestBroadcastManagerKeyframeLobbyPromotion(t *testing.T) {
+	b := NewBroadcastManager("desc", newFakePipeline)
+
+	received := make(chan []byte, 1)
+	id, err := b.AddListener(func(frame []byte) { received <- frame }, true)
+	if err != nil {
+		t.Fatalf("AddListener: %v", err)
+	}
+	defer b.RemoveListener(id)
+
+	b.mu.Lock()
+	_, inLobby := b.listenersKf[id]
+	b.mu.Unlock()
+	if !inLobby {
+		t.Fatal("expected waitForKeyframe listener to start in the lobby")
+	}
+
+	b.broadcast([]byte("frame"))
+
+	b.mu.Lock()
+	_, stillInLobby := b.listenersKf[id]
+	_, promoted := b.listeners[id]
+	b.mu.Unlock()
+	if stillInLobby || !promoted {
+		t.Fatal("expected listener to be promoted out of the lobby on a keyframe boundary")
+	}
+
+	if got := waitFor(t, received); string(got) != "frame" {
+		t.Fatalf("got frame %q, want %q", got, "frame")
+	}
+}
+
+func TestBroadcastManagerRelobbiesOnRestart(t *testing.T) {
+	b := NewBroadcastManager("desc", newFakePipeline)
+
+	id, err := b.AddListener(func([]byte) {}, true)
+	if err != nil {
+		t.Fatalf("AddListener: %v", err)
+	}
+	defer b.RemoveListener(id)
+
+	// Promote the listener, as a real stream would on its first keyframe.
+	b.broadcast([]byte("frame"))
+	b.mu.Lock()
+	_, promoted := b.listeners[id]
+	b.mu.Unlock()
+	if !promoted {
+		t.Fatal("expected listener to be promoted before restart")
+	}
+
+	if err := b.Restart(); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+
+	b.mu.Lock()
+	_, backInLobby := b.listenersKf[id]
+	_, stillPromoted := b.listeners[id]
+	b.mu.Unlock()
+	if !backInLobby || stillPromoted {
+		t.Fatal("expected a previously promoted listener to be re-lobbied on restart")
+	}
+}
+
+func TestBroadcastManagerDropsOldestOnFullBuffer(t *testing.T) {
+	b := NewBroadcastManager("desc", newFakePipeline)
+
+	// Register the listener directly, without AddListener's deliver
+	// goroutine, so nothing drains l.frames concurrently with broadcast.
+	l := &listener{id: 1, frames: make(chan []byte, listenerBufSize)}
+	b.mu.Lock()
+	b.listeners[l.id] = l
+	b.mu.Unlock()
+
+	// Push well past capacity; none of this should block or panic, and the
+	// channel should stay full rather than grow or deadlock.
+	for i := 0; i < listenerBufSize*3; i++ {
+		b.broadcast([]byte{byte(i)})
+	}
+
+	if len(l.frames) != listenerBufSize {
+		t.Fatalf("got %d buffered frames, want %d (buffer should stay full, not grow)", len(l.frames), listenerBufSize)
+	}
+
+	// The oldest frames should have been dropped: the buffer should hold
+	// the most recent listenerBufSize pushes.
+	wantFirst := byte(listenerBufSize*3 - listenerBufSize)
+	got := <-l.frames
+	if got[0] != wantFirst {
+		t.Fatalf("got oldest buffered frame %d, want %d", got[0], wantFirst)
+	}
+}
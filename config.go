@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StreamConfig describes one named capture stream the Supervisor should
+// run: its own pipeline, frame dimensions, and detector configuration.
+type StreamConfig struct {
+	Name     string  `json:"name" yaml:"name"`
+	Pipeline string  `json:"pipeline" yaml:"pipeline"`
+	Width    int     `json:"width" yaml:"width"`
+	Height   int     `json:"height" yaml:"height"`
+	Detector string  `json:"detector" yaml:"detector"` // "pigo" or "yolo"
+	Cascade  string  `json:"cascade" yaml:"cascade"`
+	Model    string  `json:"model" yaml:"model"`
+	Labels   string  `json:"labels" yaml:"labels"`
+	MinScore float64 `json:"minScore" yaml:"minScore"`
+}
+
+// Config is the top-level multi-stream configuration file.
+type Config struct {
+	Streams []StreamConfig `json:"streams" yaml:"streams"`
+}
+
+// LoadConfig reads a Config from path. YAML is assumed unless path ends in
+// ".json".
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %q: %w", path, err)
+	}
+
+	var cfg Config
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config %q: %w", path, err)
+	}
+
+	if len(cfg.Streams) == 0 {
+		return nil, fmt.Errorf("config %q declares no streams", path)
+	}
+	seen := make(map[string]bool, len(cfg.Streams))
+	for _, s := range cfg.Streams {
+		if s.Name == "" {
+			return nil, fmt.Errorf("config %q: every stream needs a name", path)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("config %q: duplicate stream name %q", path, s.Name)
+		}
+		seen[s.Name] = true
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTempConfig(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing temp config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path := writeTempConfig(t, "streams.yaml", `
+streams:
+  - name: front-door
+    pipeline: "videotestsrc ! appsink name=sink"
+    width: 640
+    height: 480
+    cascade: cascade/facefinder
+    minScore: 5.0
+  - name: driveway
+    pipeline: "videotestsrc ! appsink name=sink"
+    width: 320
+    height: 240
+    detector: yolo
+    model: model.onnx
+    labels: labels.txt
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.Streams) != 2 {
+		t.Fatalf("got %d streams, want 2", len(cfg.Streams))
+	}
+	if cfg.Streams[0].Name != "front-door" || cfg.Streams[1].Name != "driveway" {
+		t.Fatalf("unexpected stream names: %+v", cfg.Streams)
+	}
+	if cfg.Streams[1].Detector != "yolo" {
+		t.Fatalf("got detector %q, want yolo", cfg.Streams[1].Detector)
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := writeTempConfig(t, "streams.json", `{
+		"streams": [
+			{"name": "front-door", "pipeline": "videotestsrc ! appsink name=sink", "width": 640, "height": 480}
+		]
+	}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.Streams) != 1 || cfg.Streams[0].Name != "front-door" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestLoadConfigRejectsNoStreams(t *testing.T) {
+	path := writeTempConfig(t, "empty.yaml", "streams: []\n")
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for a config with no streams")
+	}
+}
+
+func TestLoadConfigRejectsUnnamedStream(t *testing.T) {
+	path := writeTempConfig(t, "unnamed.yaml", `
+streams:
+  - pipeline: "videotestsrc ! appsink name=sink"
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for a stream with no name")
+	}
+}
+
+func TestLoadConfigRejectsDuplicateNames(t *testing.T) {
+	path := writeTempConfig(t, "dup.yaml", `
+streams:
+  - name: front-door
+    pipeline: "videotestsrc ! appsink name=sink"
+  - name: front-door
+    pipeline: "videotestsrc ! appsink name=sink"
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for duplicate stream names")
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing config file")
+	}
+}
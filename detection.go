@@ -0,0 +1,32 @@
+package main
+
+// BBox is an axis-aligned bounding box in pixel coordinates, with (X, Y)
+// the top-left corner.
+type BBox struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Detection is a single detected object, shared across detector backends
+// so consumers (logging, the HTTP control plane's SSE stream, the
+// snapshot overlay) don't need to care which backend produced it.
+type Detection struct {
+	Label string
+	Box   BBox
+	Score float32
+}
+
+// Detector runs object/face detection against a single frame. Backends
+// (Pigo, YOLO, ...) negotiate their own pixel format and model loading;
+// the main loop only depends on this interface.
+type Detector interface {
+	// Detect runs detection against a frame of rows x cols pixels in the
+	// backend's expected format and returns the detections that meet its
+	// current score threshold.
+	Detect(frame []uint8, rows, cols int) []Detection
+	// Name identifies the backend, e.g. "pigo" or "yolo".
+	Name() string
+	// Close releases any resources (model handles, inference sessions)
+	// held by the backend.
+	Close() error
+}
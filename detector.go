@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sync"
+
+	pigo "github.com/esimov/pigo/core"
+)
+
+// DetectorParams holds the tunable Pigo cascade parameters. It is kept
+// separate from pigo.CascadeParams so it can be read/written as plain data
+// (e.g. decoded from a JSON request body) without dragging the frame buffer
+// along with it.
+type DetectorParams struct {
+	MinSize     int     `json:"minSize"`
+	MaxSize     int     `json:"maxSize"`
+	ShiftFactor float64 `json:"shiftFactor"`
+	ScaleFactor float64 `json:"scaleFactor"`
+	IoU         float64 `json:"iou"`
+	MinScore    float64 `json:"minScore"`
+}
+
+// DefaultDetectorParams mirrors the hard-coded values detectFaces used to
+// run with before parameters became tunable at runtime.
+var DefaultDetectorParams = DetectorParams{
+	MinSize:     100,
+	MaxSize:     600,
+	ShiftFactor: 0.15,
+	ScaleFactor: 1.1,
+	IoU:         0.0,
+	MinScore:    5.0,
+}
+
+// PigoDetector runs the Pigo cascade against GRAY8 frames, with a set of
+// parameters that can be read and swapped out at runtime by concurrent
+// callers (e.g. the HTTP control plane) without restarting detection. It
+// implements Detector.
+type PigoDetector struct {
+	classifier *pigo.Pigo
+
+	mu     sync.RWMutex
+	params DetectorParams
+}
+
+// NewPigoDetector returns a PigoDetector bound to classifier, starting out
+// with params.
+func NewPigoDetector(classifier *pigo.Pigo, params DetectorParams) *PigoDetector {
+	return &PigoDetector{classifier: classifier, params: params}
+}
+
+// Params returns the detector's current parameters.
+func (d *PigoDetector) Params() DetectorParams {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.params
+}
+
+// SetParams replaces the detector's parameters; the next Detect call picks
+// them up.
+func (d *PigoDetector) SetParams(p DetectorParams) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.params = p
+}
+
+// Name identifies this backend.
+func (d *PigoDetector) Name() string { return "pigo" }
+
+// Close is a no-op: the Pigo cascade holds no resources beyond the
+// classifier's in-memory cascade tree.
+func (d *PigoDetector) Close() error { return nil }
+
+// Detect runs the cascade against a GRAY8 frame and returns the clustered
+// detections whose score meets MinScore.
+func (d *PigoDetector) Detect(frame []uint8, rows, cols int) []Detection {
+	p := d.Params()
+
+	cParams := pigo.CascadeParams{
+		MinSize:     p.MinSize,
+		MaxSize:     p.MaxSize,
+		ShiftFactor: p.ShiftFactor,
+		ScaleFactor: p.ScaleFactor,
+		ImageParams: pigo.ImageParams{
+			Pixels: frame,
+			Rows:   rows,
+			Cols:   cols,
+			Dim:    cols,
+		},
+	}
+
+	dets := d.classifier.RunCascade(cParams, 0.0)
+	dets = d.classifier.ClusterDetections(dets, p.IoU)
+
+	out := make([]Detection, 0, len(dets))
+	for _, det := range dets {
+		if det.Q < float32(p.MinScore) {
+			continue
+		}
+		half := det.Scale / 2
+		out = append(out, Detection{
+			Label: "face",
+			Box: BBox{
+				X:      det.Col - half,
+				Y:      det.Row - half,
+				Width:  det.Scale,
+				Height: det.Scale,
+			},
+			Score: det.Q,
+		})
+	}
+	return out
+}
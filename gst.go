@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/tinyzimmer/go-gst/gst"
+	"github.com/tinyzimmer/go-gst/gst/app"
+)
+
+// rewriteCapsFormat replaces the `format=<value>` segment of a pipeline's
+// caps with format, so the same -pipeline flag can be reused across
+// detector backends that expect different pixel formats (GRAY8 for Pigo,
+// RGB for the YOLO backend) without hand-editing the GStreamer graph.
+func rewriteCapsFormat(pipeline, format string) string {
+	const prefix = "format="
+	idx := strings.Index(pipeline, prefix)
+	if idx < 0 {
+		return pipeline
+	}
+	start := idx + len(prefix)
+	end := start
+	for end < len(pipeline) && pipeline[end] != ',' && pipeline[end] != ' ' && pipeline[end] != '!' {
+		end++
+	}
+	return pipeline[:start] + format + pipeline[end:]
+}
+
+// Pipeline wraps a GStreamer pipeline built from a textual description and
+// exposes the lifecycle operations callers need (Play/Pause/Destroy) without
+// requiring them to touch the underlying gst.Pipeline or bus directly.
+type Pipeline struct {
+	mu       sync.Mutex
+	closed   bool
+	pipeline *gst.Pipeline
+	frames   chan []byte
+	done     chan struct{}
+}
+
+// NewPipeline parses desc (a gst-launch style pipeline description) and wires
+// an appsink named "sink" to deliver raw frame buffers on the returned
+// Pipeline's Frames channel. The pipeline description must end in an
+// `appsink name=sink` element in place of the old `fdsink fd=1`.
+func NewPipeline(desc string) (*Pipeline, error) {
+	gstPipeline, err := gst.NewPipelineFromString(desc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing pipeline: %w", err)
+	}
+
+	sinkElem, err := gstPipeline.GetElementByName("sink")
+	if err != nil {
+		return nil, fmt.Errorf("pipeline must contain an appsink named \"sink\": %w", err)
+	}
+	sink := app.SinkFromElement(sinkElem)
+
+	p := &Pipeline{
+		pipeline: gstPipeline,
+		frames:   make(chan []byte, 8),
+		done:     make(chan struct{}),
+	}
+
+	sink.SetCallbacks(&app.SinkCallbacks{
+		NewSampleFunc: func(s *app.Sink) gst.FlowReturn {
+			sample := s.PullSample()
+			if sample == nil {
+				return gst.FlowEOS
+			}
+			buf := sample.GetBuffer()
+			if buf == nil {
+				return gst.FlowError
+			}
+			data := buf.Map(gst.MapRead).Bytes()
+			frame := make([]byte, len(data))
+			copy(frame, data)
+			buf.Unmap()
+
+			// Hold mu for the send so it can never race closeFrames: without
+			// this, closeFrames could close p.frames between this goroutine
+			// (the GStreamer streaming thread) entering the select and
+			// actually sending, which panics.
+			p.mu.Lock()
+			if !p.closed {
+				select {
+				case p.frames <- frame:
+				default:
+					// Drop the frame rather than block the GStreamer thread.
+				}
+			}
+			p.mu.Unlock()
+			return gst.FlowOK
+		},
+		EOSFunc: func(_ *app.Sink) {
+			p.closeFrames()
+		},
+	})
+
+	bus := gstPipeline.GetPipelineBus()
+	bus.AddWatch(func(msg *gst.Message) bool {
+		switch msg.Type() {
+		case gst.MessageEOS:
+			log.Println("GStreamer pipeline reported EOS")
+			p.closeFrames()
+			return false
+		case gst.MessageError:
+			gerr := msg.ParseError()
+			log.Printf("GStreamer pipeline error: %v", gerr)
+			p.closeFrames()
+			return false
+		}
+		return true
+	})
+
+	return p, nil
+}
+
+// Frames returns the channel raw frame buffers are delivered on. It is
+// closed once the pipeline reaches EOS, errors, or is destroyed.
+func (p *Pipeline) Frames() <-chan []byte {
+	return p.frames
+}
+
+// Play starts (or resumes) the pipeline.
+func (p *Pipeline) Play() error {
+	return p.pipeline.SetState(gst.StatePlaying)
+}
+
+// Pause pauses the pipeline without tearing it down.
+func (p *Pipeline) Pause() error {
+	return p.pipeline.SetState(gst.StatePaused)
+}
+
+// Destroy stops the pipeline and releases its GStreamer resources. It is
+// safe to call more than once.
+func (p *Pipeline) Destroy() error {
+	p.closeFrames()
+	return p.pipeline.SetState(gst.StateNull)
+}
+
+func (p *Pipeline) closeFrames() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.done)
+	close(p.frames)
+}
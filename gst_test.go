@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestRewriteCapsFormat(t *testing.T) {
+	cases := []struct {
+		name     string
+		pipeline string
+		format   string
+		want     string
+	}{
+		{
+			name:     "comma terminated",
+			pipeline: "videotestsrc ! video/x-raw,format=GRAY8,width=640 ! appsink name=sink",
+			format:   "RGB",
+			want:     "videotestsrc ! video/x-raw,format=RGB,width=640 ! appsink name=sink",
+		},
+		{
+			name:     "space terminated (before !)",
+			pipeline: "videotestsrc ! video/x-raw,format=GRAY8 ! appsink name=sink",
+			format:   "RGB",
+			want:     "videotestsrc ! video/x-raw,format=RGB ! appsink name=sink",
+		},
+		{
+			name:     "end of string",
+			pipeline: "videotestsrc ! video/x-raw,format=GRAY8",
+			format:   "RGB",
+			want:     "videotestsrc ! video/x-raw,format=RGB",
+		},
+		{
+			name:     "no format present",
+			pipeline: "videotestsrc ! appsink name=sink",
+			format:   "RGB",
+			want:     "videotestsrc ! appsink name=sink",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := rewriteCapsFormat(c.pipeline, c.format); got != c.want {
+				t.Errorf("rewriteCapsFormat(%q, %q) = %q, want %q", c.pipeline, c.format, got, c.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,19 @@
 package main
 
-import "C"
-
 import (
-	"bufio"
 	"context"
 	"flag"
-	"io"
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
 	"runtime"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/tinyzimmer/go-glib/glib"
+	"github.com/tinyzimmer/go-gst/gst"
+
 	pigo "github.com/esimov/pigo/core"
 )
 
@@ -43,74 +42,16 @@ func loadCascade(path string) *pigo.Pigo {
 	return classifier
 }
 
-// detectFaces runs Pigo on a grayscale frame and returns detections.
-func detectFaces(classifier *pigo.Pigo, pixels []uint8, rows, cols int) []pigo.Detection {
-	cParams := pigo.CascadeParams{
-		MinSize:     100,
-		MaxSize:     600,
-		ShiftFactor: 0.15,
-		ScaleFactor: 1.1,
-		ImageParams: pigo.ImageParams{
-			Pixels: pixels,
-			Rows:   rows,
-			Cols:   cols,
-			Dim:    cols,
-		},
-	}
-
-	dets := classifier.RunCascade(cParams, 0.0)
-	dets = classifier.ClusterDetections(dets, 0.0)
-	return dets
-}
-
-// startGst starts a gst-launch-1.0 pipeline and returns cmd + stdout reader.
-func startGst(ctx context.Context, pipeline string) (*exec.Cmd, io.ReadCloser, error) {
-	args := append([]string{"-e"}, splitArgs(pipeline)...)
-	cmd := exec.CommandContext(ctx, "gst-launch-1.0", args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, nil, err
-	}
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Start(); err != nil {
-		_ = stdout.Close()
-		return nil, nil, err
-	}
-	log.Printf("Started gst-launch-1.0 with args: %v", cmd.Args)
-	return cmd, stdout, nil
-}
-
-// splitArgs is a minimal whitespace splitter (no full shell parsing).
-func splitArgs(s string) []string {
-	var args []string
-	current := ""
-	inQuotes := false
-
-	for i := 0; i < len(s); i++ {
-		ch := s[i]
-		switch ch {
-		case ' ':
-			if inQuotes {
-				current += string(ch)
-			} else if current != "" {
-				args = append(args, current)
-				current = ""
-			}
-		case '"':
-			inQuotes = !inQuotes
-		default:
-			current += string(ch)
-		}
-	}
-	if current != "" {
-		args = append(args, current)
-	}
-	return args
-}
-
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
+	gst.Init(nil)
+
+	// gst.Bus.AddWatch dispatches through the default GMainContext, which
+	// only gets pumped by an iterating main loop. Without this, every
+	// pipeline's EOS/error bus callbacks (gst.go, rtmp.go) simply never
+	// fire.
+	mainLoop := glib.NewMainLoop(glib.MainContextDefault(), false)
+	go mainLoop.Run()
 
 	// --- Flags ---
 	width := flag.Int("width", 640, "frame width (pixels)")
@@ -119,22 +60,24 @@ func main() {
 
 	// for macOS webcam:
 	//   avfvideosrc device-index=0 ! videoconvert ! videoscale !
-	//   video/x-raw,format=GRAY8,width=640,height=480,framerate=30/1 ! fdsink fd=1 sync=false
+	//   video/x-raw,format=GRAY8,width=640,height=480,framerate=30/1 ! appsink name=sink
 	//
 	//  for RTP/H264:
 	//   udpsrc port=5000 caps="application/x-rtp, media=video, encoding-name=H264, payload=96" !
 	//   rtph264depay ! h264parse ! avdec_h264 !
 	//   videoconvert ! videoscale !
-	//   video/x-raw,format=GRAY8,width=640,height=480,framerate=30/1 ! fdsink fd=1 sync=false
-	pipeline := flag.String("pipeline", "", "GStreamer pipeline (ending in GRAY8 video/x-raw to fdsink fd=1)")
-	minScore := flag.Float64("min-score", 5.0, "minimum detection score (Q) to report")
+	//   video/x-raw,format=GRAY8,width=640,height=480,framerate=30/1 ! appsink name=sink
+	pipeline := flag.String("pipeline", "", "GStreamer pipeline (ending in GRAY8 video/x-raw to appsink name=sink)")
+	minScore := flag.Float64("min-score", 5.0, "minimum detection score to report")
+	rtmpURL := flag.String("rtmp-url", "", "if set, re-encode and re-broadcast the capture to this RTMP URL")
+	controlAddr := flag.String("control-addr", ":8080", "address for the HTTP control plane (pipeline control, params, detections, snapshot)")
+	detectorName := flag.String("detector", "pigo", "detection backend: pigo or yolo")
+	modelPath := flag.String("model", "", "path to the detector's model file (YOLO ONNX export; unused for pigo)")
+	labelsPath := flag.String("labels", "", "path to a newline-delimited class label file (YOLO only)")
+	configPath := flag.String("config", "", "path to a multi-stream config file (YAML/JSON); takes over from -pipeline")
 
 	flag.Parse()
 
-	if *pipeline == "" {
-		log.Fatal("You must pass -pipeline with a valid GStreamer pipeline")
-	}
-
 	// --- Context + signals ---
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -147,58 +90,79 @@ func main() {
 		cancel()
 	}()
 
-	// --- Load Pigo cascade ---
-	clf := loadCascade(*cascadePath)
-	log.Println("Loaded Pigo cascade from", *cascadePath)
+	if *configPath != "" {
+		runSupervisor(ctx, *configPath, *controlAddr)
+		log.Println("Exiting.")
+		return
+	}
 
-	// --- Start GStreamer pipeline ---
-	cmd, stdout, err := startGst(ctx, *pipeline)
-	if err != nil {
-		log.Fatalf("failed to start GStreamer: %v", err)
+	if *pipeline == "" {
+		log.Fatal("You must pass -pipeline (or -config) with a valid GStreamer pipeline")
 	}
-	defer func() {
-		_ = stdout.Close()
-		if cmd.Process != nil {
-			_ = cmd.Process.Kill()
+
+	// --- Build the detector backend ---
+	var (
+		detector      Detector
+		bytesPerPixel int
+		capsFormat    = "GRAY8"
+		pipelineDesc  = *pipeline
+	)
+	switch *detectorName {
+	case "pigo":
+		clf := loadCascade(*cascadePath)
+		log.Println("Loaded Pigo cascade from", *cascadePath)
+		detParams := DefaultDetectorParams
+		detParams.MinScore = *minScore
+		detector = NewPigoDetector(clf, detParams)
+		bytesPerPixel = 1 // GRAY8
+	case "yolo":
+		labels, err := loadLabels(*labelsPath)
+		if err != nil {
+			log.Fatalf("failed to load YOLO labels: %v", err)
 		}
-	}()
+		yolo, err := NewYoloDetector(*modelPath, labels, float32(*minScore))
+		if err != nil {
+			log.Fatalf("failed to load YOLO model: %v", err)
+		}
+		detector = yolo
+		bytesPerPixel = 3 // RGB
+		capsFormat = YoloPixelFormat
+		pipelineDesc = rewriteCapsFormat(pipelineDesc, YoloPixelFormat)
+	default:
+		log.Fatalf("unknown -detector %q (want pigo or yolo)", *detectorName)
+	}
+	defer func() { _ = detector.Close() }()
 
-	reader := bufio.NewReader(stdout)
+	// --- Broadcast manager: owns the capture pipeline, fans frames out ---
+	frameSize := (*width) * (*height) * bytesPerPixel
+	manager := NewBroadcastManager(pipelineDesc, func(desc string) (pipelineSource, error) {
+		return NewPipeline(desc)
+	})
 
-	frameSize := (*width) * (*height) // GRAY8: 1 byte per pixel
-	buf := make([]byte, frameSize)
+	snap := NewSnapshotServer(*width, *height, bytesPerPixel)
+	if _, err := snap.Attach(manager); err != nil {
+		log.Fatalf("failed to attach snapshot server: %v", err)
+	}
 
-	log.Printf("Expecting GRAY8 frames of %dx%d (%d bytes)\n", *width, *height, frameSize)
+	srv := NewServer(manager, detector, snap)
 
 	frameCount := 0
 	startTime := time.Now()
 
-	for {
-		if ctx.Err() != nil {
-			log.Println("Context canceled, stopping main loop")
-			break
-		}
-
-		_, err := io.ReadFull(reader, buf)
-		if err != nil {
-			if err == io.EOF || err == io.ErrUnexpectedEOF {
-				log.Println("GStreamer pipeline ended")
-				break
-			}
-			log.Fatalf("error reading frame from GStreamer: %v", err)
+	detectorID, err := manager.AddListener(func(buf []byte) {
+		if len(buf) != frameSize {
+			log.Printf("dropping frame: got %d bytes, expected %d", len(buf), frameSize)
+			return
 		}
 
 		frameCount++
 
-		// Detect faces
-		dets := detectFaces(clf, buf, *height, *width)
+		dets := detector.Detect(buf, *height, *width)
+		srv.Publish(frameCount, dets)
 
 		for _, det := range dets {
-			if det.Q >= float32(*minScore) {
-				// Pigo returns Row, Col, Scale, Q
-				log.Printf("frame=%d face row=%d col=%d scale=%d q=%.2f",
-					frameCount, det.Row, det.Col, det.Scale, det.Q)
-			}
+			log.Printf("frame=%d %s box=%+v score=%.2f",
+				frameCount, det.Label, det.Box, det.Score)
 		}
 
 		// Simple FPS report every 60 frames
@@ -207,7 +171,61 @@ func main() {
 			fps := float64(frameCount) / elapsed
 			log.Printf("Processed %d frames (%.1f FPS)", frameCount, fps)
 		}
+	}, true)
+	if err != nil {
+		log.Fatalf("failed to start capture pipeline: %v", err)
 	}
+	defer manager.RemoveListener(detectorID)
+
+	log.Printf("Using %s detector, expecting %dx%d frames (%d bytes)\n", detector.Name(), *width, *height, frameSize)
 
+	// --- Optional RTMP re-broadcast ---
+	if *rtmpURL != "" {
+		rtmp, err := StartRTMPRebroadcast(manager, *width, *height, capsFormat, *rtmpURL)
+		if err != nil {
+			log.Fatalf("failed to start RTMP re-broadcast: %v", err)
+		}
+		defer func() { _ = rtmp.Stop() }()
+	}
+
+	// --- HTTP control plane ---
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := srv.ListenAndServe(ctx, *controlAddr); err != nil {
+			log.Fatalf("control plane stopped: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Context canceled, shutting down")
+	wg.Wait()
 	log.Println("Exiting.")
 }
+
+// runSupervisor loads a multi-stream config and runs every declared stream
+// under a Supervisor until ctx is canceled.
+func runSupervisor(ctx context.Context, configPath, controlAddr string) {
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	srv := NewSupervisorServer()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := srv.ListenAndServe(ctx, controlAddr); err != nil {
+			log.Fatalf("control plane stopped: %v", err)
+		}
+	}()
+
+	sup := NewSupervisor(srv)
+	log.Printf("Supervisor starting %d stream(s) from %s", len(cfg.Streams), configPath)
+	if err := sup.Run(ctx, cfg.Streams); err != nil {
+		log.Fatalf("supervisor stopped: %v", err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	pipelinesActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pigo_gstreamer_pipelines_active",
+		Help: "Number of capture pipelines currently running.",
+	})
+
+	framesProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pigo_gstreamer_frames_processed_total",
+		Help: "Total frames run through detection, per stream.",
+	}, []string{"stream"})
+
+	detectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pigo_gstreamer_detections_total",
+		Help: "Total detections reported, per stream.",
+	}, []string{"stream"})
+
+	streamFPS = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pigo_gstreamer_stream_fps",
+		Help: "Most recently measured frames-per-second, per stream.",
+	}, []string{"stream"})
+
+	streamRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pigo_gstreamer_stream_restarts_total",
+		Help: "Total number of times a stream's pipeline was restarted after an unexpected exit.",
+	}, []string{"stream"})
+)
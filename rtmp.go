@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/tinyzimmer/go-gst/gst"
+	"github.com/tinyzimmer/go-gst/gst/app"
+)
+
+// RTMPRebroadcaster re-encodes frames pulled from a BroadcastManager
+// listener and pushes the encoded stream out to an RTMP endpoint. It is
+// built and torn down dynamically at runtime, independent of the capture
+// pipeline's own lifecycle.
+type RTMPRebroadcaster struct {
+	mu         sync.Mutex
+	manager    *BroadcastManager
+	listenerID int
+	pipeline   *gst.Pipeline
+	src        *app.Source
+}
+
+// StartRTMPRebroadcast registers a listener on manager and builds a
+// `appsrc ! videoconvert ! x264enc ... ! flvmux ! rtmpsink location=url`
+// pipeline that re-encodes and pushes every frame it receives to url.
+// format must match the pixel format the capture pipeline is actually
+// producing (e.g. "GRAY8" for Pigo, "RGB" for the YOLO backend).
+func StartRTMPRebroadcast(manager *BroadcastManager, width, height int, format, url string) (*RTMPRebroadcaster, error) {
+	desc := fmt.Sprintf(
+		"appsrc name=src is-live=true format=time caps=video/x-raw,format=%s,width=%d,height=%d,framerate=30/1 ! "+
+			"videoconvert ! x264enc tune=zerolatency speed-preset=veryfast bitrate=2048 ! "+
+			"flvmux streamable=true ! rtmpsink location=%q",
+		format, width, height, url,
+	)
+
+	gstPipeline, err := gst.NewPipelineFromString(desc)
+	if err != nil {
+		return nil, fmt.Errorf("building RTMP pipeline: %w", err)
+	}
+
+	srcElem, err := gstPipeline.GetElementByName("src")
+	if err != nil {
+		return nil, fmt.Errorf("RTMP pipeline must contain an appsrc named \"src\": %w", err)
+	}
+
+	r := &RTMPRebroadcaster{
+		manager:  manager,
+		pipeline: gstPipeline,
+		src:      app.SrcFromElement(srcElem),
+	}
+
+	bus := gstPipeline.GetPipelineBus()
+	bus.AddWatch(func(msg *gst.Message) bool {
+		switch msg.Type() {
+		case gst.MessageEOS:
+			log.Println("RTMP re-broadcast pipeline reported EOS")
+			return false
+		case gst.MessageError:
+			gerr := msg.ParseError()
+			log.Printf("RTMP re-broadcast pipeline error: %v", gerr)
+			return false
+		}
+		return true
+	})
+
+	id, err := manager.AddListener(r.onFrame, true)
+	if err != nil {
+		return nil, fmt.Errorf("attaching RTMP listener: %w", err)
+	}
+	r.listenerID = id
+
+	if err := gstPipeline.SetState(gst.StatePlaying); err != nil {
+		manager.RemoveListener(id)
+		return nil, fmt.Errorf("starting RTMP pipeline: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RTMPRebroadcaster) onFrame(frame []byte) {
+	buf := gst.NewBufferFromBytes(frame)
+	r.src.PushBuffer(buf)
+}
+
+// Stop detaches the rebroadcaster from its BroadcastManager and tears down
+// the RTMP pipeline.
+func (r *RTMPRebroadcaster) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.manager.RemoveListener(r.listenerID)
+	return r.pipeline.SetState(gst.StateNull)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DetectionEvent is one frame's worth of detections, as published to the
+// control plane's SSE/NDJSON subscribers. Stream is empty in single-stream
+// mode and set to the originating stream's name when run under a
+// Supervisor.
+type DetectionEvent struct {
+	Stream     string      `json:"stream,omitempty"`
+	Frame      int         `json:"frame"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Detections []Detection `json:"detections"`
+}
+
+// Server is the embedded HTTP control plane: it lets operators start/stop
+// and reconfigure the capture pipeline, tune detector parameters without a
+// restart, and stream detections or the latest snapshot. manager and
+// detector are nil when the control plane fronts a Supervisor running
+// multiple streams, since "the" pipeline/detector is no longer singular;
+// the corresponding routes report 404 in that case.
+type Server struct {
+	manager  *BroadcastManager
+	detector Detector
+	snapshot *SnapshotServer
+
+	mu          sync.Mutex
+	subscribers map[int]chan DetectionEvent
+	nextSubID   int
+}
+
+// NewServer returns a control plane server wired to manager, detector, and
+// an optional snapshot source (nil disables the /snapshot.jpg route).
+func NewServer(manager *BroadcastManager, detector Detector, snapshot *SnapshotServer) *Server {
+	return &Server{
+		manager:     manager,
+		detector:    detector,
+		snapshot:    snapshot,
+		subscribers: make(map[int]chan DetectionEvent),
+	}
+}
+
+// NewSupervisorServer returns a control plane server for multi-stream
+// (Supervisor) mode: no single pipeline/detector to control directly, just
+// the aggregated /detections stream and /metrics.
+func NewSupervisorServer() *Server {
+	return &Server{subscribers: make(map[int]chan DetectionEvent)}
+}
+
+// Publish fans a frame's detections out to every connected SSE/NDJSON
+// subscriber. An event is dropped for a subscriber whose buffer is full
+// rather than allowed to block detection; the subscriber stays connected.
+func (s *Server) Publish(frame int, dets []Detection) {
+	s.publish(DetectionEvent{Frame: frame, Timestamp: time.Now(), Detections: dets})
+}
+
+// PublishStream is Publish, tagged with the originating stream's name.
+// Used by the Supervisor so subscribers can tell streams apart.
+func (s *Server) PublishStream(stream string, frame int, dets []Detection) {
+	s.publish(DetectionEvent{Stream: stream, Frame: frame, Timestamp: time.Now(), Detections: dets})
+}
+
+func (s *Server) publish(evt DetectionEvent) {
+	if s.snapshot != nil {
+		s.snapshot.SetDetections(evt.Detections)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.subscribers {
+		select {
+		case ch <- evt:
+		default:
+			// Drop the event for this subscriber; it'll catch up on the next one.
+		}
+	}
+}
+
+// Handler builds the control plane's routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pipeline/start", s.handleStart)
+	mux.HandleFunc("/pipeline/stop", s.handleStop)
+	mux.HandleFunc("/pipeline", s.handlePipeline)
+	mux.HandleFunc("/params", s.handleParams)
+	mux.HandleFunc("/detections", s.handleDetections)
+	mux.Handle("/metrics", promhttp.Handler())
+	if s.snapshot != nil {
+		mux.Handle("/snapshot.jpg", s.snapshot)
+	}
+	return mux
+}
+
+// ListenAndServe runs the control plane on addr until ctx is canceled, at
+// which point it shuts the HTTP server down cleanly.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Control plane listening on http://%s", addr)
+	err := srv.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	if s.manager == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.manager.Start(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	if s.manager == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.manager.Stop()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePipeline swaps the pipeline description and rebuilds the
+// GStreamer graph. Only PUT is supported; the current description cannot
+// be read back through this route.
+func (s *Server) handlePipeline(w http.ResponseWriter, r *http.Request) {
+	if s.manager == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Pipeline string `json:"pipeline"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if body.Pipeline == "" {
+		http.Error(w, "pipeline must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	s.manager.SetPipelineDesc(body.Pipeline)
+	if err := s.manager.Restart(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleParams exposes live-tunable cascade parameters. It only applies to
+// the Pigo backend today; other backends report 501 until they grow an
+// equivalent tunable parameter set.
+func (s *Server) handleParams(w http.ResponseWriter, r *http.Request) {
+	if s.detector == nil {
+		http.NotFound(w, r)
+		return
+	}
+	pigoDet, ok := s.detector.(*PigoDetector)
+	if !ok {
+		http.Error(w, fmt.Sprintf("backend %q does not support live parameter tuning", s.detector.Name()), http.StatusNotImplemented)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(pigoDet.Params())
+	case http.MethodPut:
+		var p DetectorParams
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		pigoDet.SetParams(p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDetections streams DetectionEvents to the client as
+// Server-Sent Events, one `data: <json>` line per detected frame.
+func (s *Server) handleDetections(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan DetectionEvent, 16)
+	s.mu.Lock()
+	id := s.nextSubID
+	s.nextSubID++
+	s.subscribers[id] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, id)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt := <-ch:
+			data, err := json.Marshal(evt)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDetector is a Detector test double that isn't *PigoDetector, used to
+// exercise handleParams' "backend doesn't support tuning" path.
+type fakeDetector struct{}
+
+func (fakeDetector) Detect(frame []uint8, rows, cols int) []Detection { return nil }
+func (fakeDetector) Name() string                                     { return "fake" }
+func (fakeDetector) Close() error                                     { return nil }
+
+func TestHandleStartStopRequireManager(t *testing.T) {
+	srv := NewSupervisorServer()
+
+	for _, path := range []string{"/pipeline/start", "/pipeline/stop"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		srv.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s with no manager: got status %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestHandleStartStop(t *testing.T) {
+	manager := NewBroadcastManager("good", newFakePipeline)
+	srv := NewServer(manager, fakeDetector{}, nil)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pipeline/start", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /pipeline/start: got status %d, want 405", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pipeline/start", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("POST /pipeline/start: got status %d, want 204", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pipeline/stop", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("POST /pipeline/stop: got status %d, want 204", rec.Code)
+	}
+}
+
+func TestHandlePipeline(t *testing.T) {
+	manager := NewBroadcastManager("good", newFakePipeline)
+	srv := NewServer(manager, fakeDetector{}, nil)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pipeline", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /pipeline: got status %d, want 405", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/pipeline", strings.NewReader("not json"))
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT /pipeline with invalid JSON: got status %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	body, _ := json.Marshal(map[string]string{"pipeline": ""})
+	req = httptest.NewRequest(http.MethodPut, "/pipeline", bytes.NewReader(body))
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT /pipeline with empty pipeline: got status %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	body, _ = json.Marshal(map[string]string{"pipeline": "good"})
+	req = httptest.NewRequest(http.MethodPut, "/pipeline", bytes.NewReader(body))
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("PUT /pipeline with a valid pipeline: got status %d, want 204", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	body, _ = json.Marshal(map[string]string{"pipeline": "bad"})
+	req = httptest.NewRequest(http.MethodPut, "/pipeline", bytes.NewReader(body))
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("PUT /pipeline with a pipeline that fails to start: got status %d, want 500", rec.Code)
+	}
+}
+
+func TestHandleParams(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewSupervisorServer().Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/params", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("/params with no detector: got status %d, want 404", rec.Code)
+	}
+
+	fakeSrv := NewServer(nil, fakeDetector{}, nil)
+	rec = httptest.NewRecorder()
+	fakeSrv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/params", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("/params with a non-pigo detector: got status %d, want 501", rec.Code)
+	}
+
+	pigoDet := NewPigoDetector(nil, DefaultDetectorParams)
+	pigoSrv := NewServer(nil, pigoDet, nil)
+
+	rec = httptest.NewRecorder()
+	pigoSrv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/params", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /params: got status %d, want 200", rec.Code)
+	}
+	var got DetectorParams
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding /params response: %v", err)
+	}
+	if got != DefaultDetectorParams {
+		t.Errorf("got params %+v, want %+v", got, DefaultDetectorParams)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/params", strings.NewReader("not json"))
+	pigoSrv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT /params with invalid JSON: got status %d, want 400", rec.Code)
+	}
+
+	newParams := DefaultDetectorParams
+	newParams.MinScore = 9.5
+	body, _ := json.Marshal(newParams)
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/params", bytes.NewReader(body))
+	pigoSrv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("PUT /params: got status %d, want 204", rec.Code)
+	}
+	if pigoDet.Params().MinScore != 9.5 {
+		t.Errorf("got MinScore %v after PUT, want 9.5", pigoDet.Params().MinScore)
+	}
+}
+
+func TestHandleDetectionsSSE(t *testing.T) {
+	srv := NewSupervisorServer()
+
+	rec := httptest.NewRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/detections", nil).WithContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		srv.handleDetections(rec, req)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		srv.mu.Lock()
+		n := len(srv.subscribers)
+		srv.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("handler never registered as a subscriber")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	srv.Publish(7, []Detection{{Label: "face", Score: 0.9}})
+
+	deadline = time.Now().Add(time.Second)
+	for rec.Body.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for an SSE event")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handleDetections did not return after context cancellation")
+	}
+
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "data: ") {
+		t.Fatalf("expected SSE body to start with %q, got %q", "data: ", body)
+	}
+	if !strings.Contains(body, `"frame":7`) || !strings.Contains(body, `"face"`) {
+		t.Fatalf("SSE body missing expected event fields: %q", body)
+	}
+}
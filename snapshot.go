@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+	"sync"
+)
+
+// SnapshotServer serves the most recently captured frame as an annotated
+// JPEG image over HTTP, fed by registering itself as a BroadcastManager
+// listener. The Server that owns it keeps it updated with the latest
+// published detections so the served image has boxes drawn on it.
+type SnapshotServer struct {
+	width, height, bytesPerPixel int
+
+	mu         sync.RWMutex
+	frame      []byte
+	detections []Detection
+}
+
+// NewSnapshotServer returns a SnapshotServer for frames of the given
+// dimensions and bytesPerPixel (1 for GRAY8, 3 for interleaved RGB). Call
+// Attach to start receiving frames from a BroadcastManager.
+func NewSnapshotServer(width, height, bytesPerPixel int) *SnapshotServer {
+	return &SnapshotServer{width: width, height: height, bytesPerPixel: bytesPerPixel}
+}
+
+// Attach registers the snapshot server as a listener on manager and returns
+// the listener id, which can later be passed to manager.RemoveListener to
+// detach it. It waits for the next keyframe boundary before taking in
+// frames, so it never stores a partial frame; after a restart the last
+// frame stored before it keeps being served until a new one arrives.
+func (s *SnapshotServer) Attach(manager *BroadcastManager) (int, error) {
+	return manager.AddListener(s.onFrame, true)
+}
+
+func (s *SnapshotServer) onFrame(frame []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frame = frame
+}
+
+// SetDetections updates the detections drawn as overlay boxes on the next
+// snapshot served. The Server calls this every time it publishes a frame's
+// detections.
+func (s *SnapshotServer) SetDetections(dets []Detection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detections = dets
+}
+
+// ServeHTTP writes the latest frame as a JPEG with the latest detections
+// drawn as box outlines, or 503 if no frame has been captured yet.
+func (s *SnapshotServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	frame := s.frame
+	dets := s.detections
+	s.mu.RUnlock()
+
+	if frame == nil || len(frame) != s.width*s.height*s.bytesPerPixel {
+		http.Error(w, "no frame available yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	var img image.Image
+	switch s.bytesPerPixel {
+	case 1:
+		// Copy so the overlay isn't drawn into the frame buffer shared
+		// with the other listeners.
+		pix := make([]byte, len(frame))
+		copy(pix, frame)
+		img = &image.Gray{
+			Pix:    pix,
+			Stride: s.width,
+			Rect:   image.Rect(0, 0, s.width, s.height),
+		}
+	case 3:
+		rgba := image.NewNRGBA(image.Rect(0, 0, s.width, s.height))
+		for i := 0; i < s.width*s.height; i++ {
+			rgba.Pix[i*4] = frame[i*3]
+			rgba.Pix[i*4+1] = frame[i*3+1]
+			rgba.Pix[i*4+2] = frame[i*3+2]
+			rgba.Pix[i*4+3] = 0xff
+		}
+		img = rgba
+	default:
+		http.Error(w, "unsupported pixel format for snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	if annotated, ok := img.(draw.Image); ok {
+		for _, det := range dets {
+			drawBoxOutline(annotated, det.Box, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		http.Error(w, "failed to encode snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// drawBoxOutline draws a one-pixel-wide rectangle outline for box onto img,
+// clipped to img's bounds (Set on standard library image types is a no-op
+// outside Bounds()).
+func drawBoxOutline(img draw.Image, box BBox, c color.Color) {
+	x0, y0 := box.X, box.Y
+	x1, y1 := box.X+box.Width, box.Y+box.Height
+
+	for x := x0; x < x1; x++ {
+		img.Set(x, y0, c)
+		img.Set(x, y1-1, c)
+	}
+	for y := y0; y < y1; y++ {
+		img.Set(x0, y, c)
+		img.Set(x1-1, y, c)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDrawBoxOutline(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	box := BBox{X: 2, Y: 2, Width: 4, Height: 4}
+
+	drawBoxOutline(img, box, color.White)
+
+	corners := []image.Point{{2, 2}, {5, 2}, {2, 5}, {5, 5}}
+	for _, p := range corners {
+		if g, _, _, _ := img.At(p.X, p.Y).RGBA(); g == 0 {
+			t.Errorf("expected corner %v to be drawn on", p)
+		}
+	}
+
+	if g, _, _, _ := img.At(0, 0).RGBA(); g != 0 {
+		t.Errorf("expected pixel outside the box to be left untouched")
+	}
+}
+
+func TestDrawBoxOutlineClipsToBounds(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	box := BBox{X: -5, Y: -5, Width: 8, Height: 8}
+
+	// Must not panic even though the box starts outside the image bounds.
+	drawBoxOutline(img, box, color.White)
+}
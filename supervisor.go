@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	pigo "github.com/esimov/pigo/core"
+)
+
+// restartBackoffMin and restartBackoffMax bound the exponential backoff a
+// Supervisor applies between restart attempts for a stream whose pipeline
+// exited unexpectedly.
+const (
+	restartBackoffMin = 1 * time.Second
+	restartBackoffMax = 30 * time.Second
+)
+
+// streamRuntime holds everything the Supervisor owns for one configured
+// stream: its capture pipeline, detector, and per-stream frame/FPS
+// bookkeeping. frameCount and startTime are touched both by the listener
+// goroutine that owns frame delivery and by restartWithBackoff after a
+// successful restart, so they're guarded by mu rather than assumed to
+// belong to a single goroutine.
+type streamRuntime struct {
+	cfg      StreamConfig
+	manager  *BroadcastManager
+	detector Detector
+
+	mu         sync.Mutex
+	frameCount int
+	startTime  time.Time
+}
+
+// recordFrame increments the frame count and returns the new count along
+// with the FPS measured since startTime.
+func (rt *streamRuntime) recordFrame() (count int, fps float64) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.frameCount++
+	return rt.frameCount, float64(rt.frameCount) / time.Since(rt.startTime).Seconds()
+}
+
+// reset clears the frame count and restarts the FPS clock, used after a
+// successful restart.
+func (rt *streamRuntime) reset() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.frameCount = 0
+	rt.startTime = time.Now()
+}
+
+// Supervisor runs N independently configured capture streams side by
+// side, each with its own GStreamer pipeline and detector, tags every
+// detection with its stream name, and restarts a stream with exponential
+// backoff if its pipeline exits unexpectedly.
+type Supervisor struct {
+	srv *Server
+}
+
+// NewSupervisor returns a Supervisor that publishes every stream's
+// detections through srv (so they all share one control plane).
+func NewSupervisor(srv *Server) *Supervisor {
+	return &Supervisor{srv: srv}
+}
+
+// Run builds and starts one stream per cfg entry, then blocks until ctx is
+// canceled, at which point every stream's pipeline is torn down.
+func (s *Supervisor) Run(ctx context.Context, streams []StreamConfig) error {
+	runtimes := make([]*streamRuntime, len(streams))
+	for i, cfg := range streams {
+		rt, err := s.buildStream(cfg)
+		if err != nil {
+			return fmt.Errorf("stream %q: %w", cfg.Name, err)
+		}
+		runtimes[i] = rt
+	}
+
+	for _, rt := range runtimes {
+		rt := rt
+		pipelinesActive.Inc()
+		if err := rt.manager.Start(); err != nil {
+			return fmt.Errorf("stream %q: starting pipeline: %w", rt.cfg.Name, err)
+		}
+		rt.manager.SetOnEnded(func() {
+			pipelinesActive.Dec()
+			go s.restartWithBackoff(ctx, rt)
+		})
+	}
+
+	<-ctx.Done()
+	for _, rt := range runtimes {
+		rt.manager.Stop()
+		_ = rt.detector.Close()
+	}
+	return nil
+}
+
+// buildStream constructs the detector and broadcast manager for one
+// configured stream and attaches the detector listener, which starts the
+// manager's capture pipeline.
+func (s *Supervisor) buildStream(cfg StreamConfig) (*streamRuntime, error) {
+	var (
+		detector      Detector
+		bytesPerPixel int
+		pipelineDesc  = cfg.Pipeline
+	)
+
+	switch cfg.Detector {
+	case "", "pigo":
+		data, err := os.ReadFile(cfg.Cascade)
+		if err != nil {
+			return nil, fmt.Errorf("reading cascade: %w", err)
+		}
+		clf, err := pigo.NewPigo().Unpack(data)
+		if err != nil {
+			return nil, fmt.Errorf("unpacking cascade: %w", err)
+		}
+		params := DefaultDetectorParams
+		params.MinScore = cfg.MinScore
+		detector = NewPigoDetector(clf, params)
+		bytesPerPixel = 1 // GRAY8
+	case "yolo":
+		labels, err := loadLabels(cfg.Labels)
+		if err != nil {
+			return nil, err
+		}
+		yolo, err := NewYoloDetector(cfg.Model, labels, float32(cfg.MinScore))
+		if err != nil {
+			return nil, err
+		}
+		detector = yolo
+		bytesPerPixel = 3 // RGB
+		pipelineDesc = rewriteCapsFormat(pipelineDesc, YoloPixelFormat)
+	default:
+		return nil, fmt.Errorf("unknown detector %q", cfg.Detector)
+	}
+
+	manager := NewBroadcastManager(pipelineDesc, func(desc string) (pipelineSource, error) {
+		return NewPipeline(desc)
+	})
+
+	rt := &streamRuntime{cfg: cfg, manager: manager, detector: detector, startTime: time.Now()}
+	frameSize := cfg.Width * cfg.Height * bytesPerPixel
+
+	if _, err := manager.AddListener(func(buf []byte) {
+		if len(buf) != frameSize {
+			log.Printf("stream=%s dropping frame: got %d bytes, expected %d", cfg.Name, len(buf), frameSize)
+			return
+		}
+
+		framesProcessed.WithLabelValues(cfg.Name).Inc()
+
+		dets := detector.Detect(buf, cfg.Height, cfg.Width)
+		detectionsTotal.WithLabelValues(cfg.Name).Add(float64(len(dets)))
+
+		frameCount, fps := rt.recordFrame()
+		s.srv.PublishStream(cfg.Name, frameCount, dets)
+
+		if frameCount%60 == 0 {
+			streamFPS.WithLabelValues(cfg.Name).Set(fps)
+			log.Printf("stream=%s frame=%d fps=%.1f", cfg.Name, frameCount, fps)
+		}
+	}, true); err != nil {
+		return nil, fmt.Errorf("attaching detector listener: %w", err)
+	}
+
+	return rt, nil
+}
+
+// nextBackoff doubles backoff, capped at restartBackoffMax.
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > restartBackoffMax {
+		backoff = restartBackoffMax
+	}
+	return backoff
+}
+
+// restartWithBackoff retries Start with exponential backoff (capped at
+// restartBackoffMax, with jitter) until it succeeds or ctx is canceled.
+func (s *Supervisor) restartWithBackoff(ctx context.Context, rt *streamRuntime) {
+	backoff := restartBackoffMin
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))):
+		}
+
+		log.Printf("stream=%s restarting pipeline after unexpected exit", rt.cfg.Name)
+		streamRestarts.WithLabelValues(rt.cfg.Name).Inc()
+
+		if err := rt.manager.Start(); err != nil {
+			log.Printf("stream=%s restart failed: %v", rt.cfg.Name, err)
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		pipelinesActive.Inc()
+		streamRestarts.WithLabelValues(rt.cfg.Name).Inc()
+		rt.reset()
+		return
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBackoffDoublesUntilCap(t *testing.T) {
+	cases := []struct {
+		in   time.Duration
+		want time.Duration
+	}{
+		{restartBackoffMin, 2 * time.Second},
+		{2 * time.Second, 4 * time.Second},
+		{20 * time.Second, restartBackoffMax},
+		{restartBackoffMax, restartBackoffMax},
+	}
+
+	for _, c := range cases {
+		if got := nextBackoff(c.in); got != c.want {
+			t.Errorf("nextBackoff(%s) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
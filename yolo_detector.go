@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// loadLabels reads a newline-delimited class label file, in the order the
+// model's output channels expect.
+func loadLabels(path string) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-labels is required when using the yolo detector")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening labels file: %w", err)
+	}
+	defer f.Close()
+
+	var labels []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			labels = append(labels, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading labels file: %w", err)
+	}
+	return labels, nil
+}
+
+// YoloPixelFormat is the GStreamer caps `format=` value the YOLO backend
+// expects its input frames in.
+const YoloPixelFormat = "RGB"
+
+// yoloInputSize is the square input resolution the bundled YOLO ONNX
+// models are trained for.
+const yoloInputSize = 640
+
+// nmsIoUThreshold is the overlap above which two same-class boxes are
+// considered the same detection during non-max suppression.
+const nmsIoUThreshold = 0.45
+
+// YoloDetector runs object detection via an ONNX-exported YOLO model using
+// onnxruntime-go. Unlike PigoDetector it takes interleaved RGB frames, not
+// GRAY8, and reports detections across the model's full label set rather
+// than a single "face" class. It implements Detector.
+type YoloDetector struct {
+	session  *ort.AdvancedSession
+	input    *ort.Tensor[float32]
+	output   *ort.Tensor[float32]
+	labels   []string
+	minScore float32
+}
+
+// NewYoloDetector loads the ONNX model at modelPath and prepares an
+// inference session sized for yoloInputSize x yoloInputSize RGB input.
+// labels is the ordered class name list the model was trained against.
+func NewYoloDetector(modelPath string, labels []string, minScore float32) (*YoloDetector, error) {
+	input, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 3, yoloInputSize, yoloInputSize))
+	if err != nil {
+		return nil, fmt.Errorf("allocating YOLO input tensor: %w", err)
+	}
+
+	numClasses := len(labels)
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(1, int64(4+numClasses), 8400))
+	if err != nil {
+		_ = input.Destroy()
+		return nil, fmt.Errorf("allocating YOLO output tensor: %w", err)
+	}
+
+	session, err := ort.NewAdvancedSession(modelPath,
+		[]string{"images"}, []string{"output0"},
+		[]ort.ArbitraryTensor{input}, []ort.ArbitraryTensor{output}, nil)
+	if err != nil {
+		_ = input.Destroy()
+		_ = output.Destroy()
+		return nil, fmt.Errorf("creating ONNX Runtime session for %q: %w", modelPath, err)
+	}
+
+	return &YoloDetector{
+		session:  session,
+		input:    input,
+		output:   output,
+		labels:   labels,
+		minScore: minScore,
+	}, nil
+}
+
+// Name identifies this backend.
+func (d *YoloDetector) Name() string { return "yolo" }
+
+// Close releases the ONNX Runtime session and its tensors.
+func (d *YoloDetector) Close() error {
+	_ = d.session.Destroy()
+	_ = d.input.Destroy()
+	_ = d.output.Destroy()
+	return nil
+}
+
+// Detect runs inference against an interleaved RGB frame of rows x cols
+// pixels and returns detections above minScore, in the source frame's
+// coordinate space.
+func (d *YoloDetector) Detect(frame []uint8, rows, cols int) []Detection {
+	d.letterbox(frame, rows, cols)
+
+	if err := d.session.Run(); err != nil {
+		return nil
+	}
+
+	return d.decode(rows, cols)
+}
+
+// letterbox resizes frame (rows x cols, interleaved RGB) into the model's
+// fixed yoloInputSize x yoloInputSize input tensor, normalizing pixels to
+// [0, 1] and converting HWC -> CHW as the ONNX export expects.
+func (d *YoloDetector) letterbox(frame []uint8, rows, cols int) {
+	data := d.input.GetData()
+	scale := float64(yoloInputSize) / float64(max(rows, cols))
+
+	for y := 0; y < yoloInputSize; y++ {
+		srcY := int(float64(y) / scale)
+		if srcY >= rows {
+			continue
+		}
+		for x := 0; x < yoloInputSize; x++ {
+			srcX := int(float64(x) / scale)
+			if srcX >= cols {
+				continue
+			}
+			srcOff := (srcY*cols + srcX) * 3
+			dstOff := y*yoloInputSize + x
+			plane := yoloInputSize * yoloInputSize
+			data[dstOff] = float32(frame[srcOff]) / 255
+			data[plane+dstOff] = float32(frame[srcOff+1]) / 255
+			data[2*plane+dstOff] = float32(frame[srcOff+2]) / 255
+		}
+	}
+}
+
+// decode turns the raw YOLO output tensor into Detections scaled back to
+// the original rows x cols frame, keeping only the highest-scoring class
+// per candidate box and applying minScore as a cutoff.
+func (d *YoloDetector) decode(rows, cols int) []Detection {
+	data := d.output.GetData()
+	numClasses := len(d.labels)
+	numBoxes := 8400
+	scale := float64(max(rows, cols)) / float64(yoloInputSize)
+
+	var dets []Detection
+	for i := 0; i < numBoxes; i++ {
+		bestScore := float32(0)
+		bestClass := -1
+		for c := 0; c < numClasses; c++ {
+			score := data[(4+c)*numBoxes+i]
+			if score > bestScore {
+				bestScore = score
+				bestClass = c
+			}
+		}
+		if bestClass < 0 || bestScore < d.minScore {
+			continue
+		}
+
+		cx := float64(data[0*numBoxes+i]) * scale
+		cy := float64(data[1*numBoxes+i]) * scale
+		w := float64(data[2*numBoxes+i]) * scale
+		h := float64(data[3*numBoxes+i]) * scale
+
+		dets = append(dets, Detection{
+			Label: d.labels[bestClass],
+			Box: BBox{
+				X:      int(cx - w/2),
+				Y:      int(cy - h/2),
+				Width:  int(w),
+				Height: int(h),
+			},
+			Score: bestScore,
+		})
+	}
+
+	sort.Slice(dets, func(i, j int) bool { return dets[i].Score > dets[j].Score })
+	return nonMaxSuppress(dets, nmsIoUThreshold)
+}
+
+// nonMaxSuppress greedily keeps the highest-scoring box in each cluster of
+// overlapping same-label detections and drops the rest. dets must already
+// be sorted by descending score.
+func nonMaxSuppress(dets []Detection, iouThreshold float64) []Detection {
+	kept := make([]Detection, 0, len(dets))
+	for _, cand := range dets {
+		overlaps := false
+		for _, k := range kept {
+			if k.Label == cand.Label && iou(k.Box, cand.Box) > iouThreshold {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, cand)
+		}
+	}
+	return kept
+}
+
+// iou returns the intersection-over-union of two boxes, in [0, 1].
+func iou(a, b BBox) float64 {
+	ix1, iy1 := max(a.X, b.X), max(a.Y, b.Y)
+	ix2, iy2 := min(a.X+a.Width, b.X+b.Width), min(a.Y+a.Height, b.Y+b.Height)
+
+	iw, ih := ix2-ix1, iy2-iy1
+	if iw <= 0 || ih <= 0 {
+		return 0
+	}
+
+	intersection := float64(iw * ih)
+	union := float64(a.Width*a.Height+b.Width*b.Height) - intersection
+	if union <= 0 {
+		return 0
+	}
+	return intersection / union
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
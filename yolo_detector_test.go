@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestIoU(t *testing.T) {
+	a := BBox{X: 0, Y: 0, Width: 10, Height: 10}
+
+	cases := []struct {
+		name string
+		b    BBox
+		want float64
+	}{
+		{"identical", BBox{X: 0, Y: 0, Width: 10, Height: 10}, 1},
+		{"disjoint", BBox{X: 20, Y: 20, Width: 10, Height: 10}, 0},
+		{"half overlap", BBox{X: 5, Y: 0, Width: 10, Height: 10}, 50.0 / 150.0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := iou(a, c.b); got != c.want {
+				t.Errorf("iou(%+v, %+v) = %v, want %v", a, c.b, got, c.want)
+			}
+		})
+	}
+}
+
+func TestNonMaxSuppress(t *testing.T) {
+	dets := []Detection{
+		{Label: "person", Score: 0.9, Box: BBox{X: 0, Y: 0, Width: 10, Height: 10}},
+		{Label: "person", Score: 0.8, Box: BBox{X: 1, Y: 1, Width: 10, Height: 10}}, // overlaps the first
+		{Label: "dog", Score: 0.7, Box: BBox{X: 0, Y: 0, Width: 10, Height: 10}},    // same box, different label
+		{Label: "person", Score: 0.6, Box: BBox{X: 50, Y: 50, Width: 10, Height: 10}},
+	}
+
+	got := nonMaxSuppress(dets, 0.45)
+
+	if len(got) != 3 {
+		t.Fatalf("got %d detections, want 3: %+v", len(got), got)
+	}
+	for _, d := range got {
+		if d.Label == "person" && d.Score == 0.8 {
+			t.Fatalf("expected the lower-scoring overlapping box to be suppressed, got %+v", got)
+		}
+	}
+}